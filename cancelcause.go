@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+//contextWithCancellableReason decorates a context.Context so that Err
+//reports the error of the peer Runnable that triggered cancellation
+//alongside context.Canceled, instead of the generic context.Canceled alone
+type contextWithCancellableReason struct {
+	context.Context
+
+	mu  sync.Mutex
+	err error
+}
+
+//withCancelCause returns a contextWithCancellableReason derived from parent
+//together with the context.CancelFunc that cancels it
+func withCancelCause(parent context.Context) (*contextWithCancellableReason, context.CancelFunc) {
+	inner, cancel := context.WithCancel(parent)
+	return &contextWithCancellableReason{Context: inner}, cancel
+}
+
+//Err returns the recorded cancellation cause, wrapping context.Canceled and
+//the triggering app's error, once cancelCause has been called; otherwise it
+//defers to the embedded context
+func (c *contextWithCancellableReason) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return c.Context.Err()
+}
+
+//cancelCause records err as the reason this context was canceled. Only the
+//first call takes effect so the error of the first failing app is preserved
+func (c *contextWithCancellableReason) cancelCause(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = multierror.Append(&multierror.Error{}, context.Canceled, err)
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContextWithCancellableReason_DefersToParentUntilCauseRecorded(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	defer cancelParent()
+
+	cctx, cancel := withCancelCause(parent)
+	defer cancel()
+
+	if err := cctx.Err(); err != nil {
+		t.Fatalf("Err() before cancellation = %v, want nil", err)
+	}
+
+	cancelParent()
+	<-cctx.Done()
+
+	if !errors.Is(cctx.Err(), context.Canceled) {
+		t.Fatalf("Err() = %v, want context.Canceled (no cause was ever recorded)", cctx.Err())
+	}
+}
+
+func TestContextWithCancellableReason_FirstCauseWins(t *testing.T) {
+	first := errors.New("first")
+	second := errors.New("second")
+
+	cctx, cancel := withCancelCause(context.Background())
+	defer cancel()
+
+	cctx.cancelCause(first)
+	cctx.cancelCause(second)
+
+	err := cctx.Err()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Err() = %v, want it to wrap context.Canceled", err)
+	}
+	if !errors.Is(err, first) {
+		t.Fatalf("Err() = %v, want it to wrap the first recorded cause %v", err, first)
+	}
+	if errors.Is(err, second) {
+		t.Fatalf("Err() = %v, want the second cancelCause call to have been ignored", err)
+	}
+}
+
+func TestContextWithCancellableReason_PeerObservesSiblingCause(t *testing.T) {
+	cctx, cancel := withCancelCause(context.Background())
+	defer cancel()
+
+	cause := errors.New("peer failed")
+
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		cctx.cancelCause(cause)
+		cancel()
+	}()
+
+	<-recorded
+	<-cctx.Done()
+
+	if err := cctx.Err(); !errors.Is(err, cause) {
+		t.Fatalf("peer observed Err() = %v, want it to wrap the sibling's cause %v", err, cause)
+	}
+}
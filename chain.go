@@ -0,0 +1,17 @@
+package runner
+
+//Middleware wraps a Runnable, returning a decorated Runnable with the same
+//contract. Built-in implementations - retry, timeout, circuit breaker and
+//bulkhead - live in the runner/middleware subpackage
+type Middleware func(Runnable) Runnable
+
+//Chain wraps r with mws, applying them in the order given so that the first
+//middleware is the outermost one, e.g.
+//  Chain(app, middleware.Retry(3, nil), middleware.Timeout(30*time.Second))
+//retries the whole per-attempt timeout, not the other way around
+func Chain(r Runnable, mws ...Middleware) Runnable {
+	for i := len(mws) - 1; i >= 0; i-- {
+		r = mws[i](r)
+	}
+	return r
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+//Lifecycle is an optional interface a Runnable may implement to take part in
+//ordered startup and graceful shutdown instead of relying solely on Run(ctx)
+//returning. Runner detects it via type assertion
+type Lifecycle interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+//StartupOrder controls how Runner starts apps that implement Lifecycle
+type StartupOrder int
+
+const (
+	//Parallel starts every Lifecycle app concurrently. This is the default
+	Parallel StartupOrder = iota
+	//Sequential starts Lifecycle apps one at a time, in registration order,
+	//waiting for each Start to return before starting the next - useful for
+	//dependency-ordered apps such as a DB that must be up before an HTTP
+	//server starts accepting requests
+	Sequential
+)
+
+//lifecycleApps returns the subset of r.apps implementing Lifecycle, in
+//registration order
+func (r *Runner) lifecycleApps() []Lifecycle {
+	var lifecycles []Lifecycle
+	for _, a := range r.apps {
+		if l, ok := a.(Lifecycle); ok {
+			lifecycles = append(lifecycles, l)
+		}
+	}
+	return lifecycles
+}
+
+//startLifecycles starts every Lifecycle app in r.apps honoring r.startupOrder.
+//It returns the Lifecycle apps whose Start call actually succeeded, in
+//registration order, together with the first error encountered, if any - the
+//caller must only Stop the returned apps, since the rest never came up
+func (r *Runner) startLifecycles(ctx context.Context) ([]Lifecycle, error) {
+	lifecycles := r.lifecycleApps()
+
+	if r.startupOrder == Sequential {
+		for i, l := range lifecycles {
+			if err := l.Start(ctx); err != nil {
+				return lifecycles[:i], err
+			}
+		}
+		return lifecycles, nil
+	}
+
+	type result struct {
+		index int
+		err   error
+	}
+
+	results := make(chan result, len(lifecycles))
+	for i, l := range lifecycles {
+		go func(i int, l Lifecycle) { results <- result{index: i, err: l.Start(ctx)} }(i, l)
+	}
+
+	started := make([]bool, len(lifecycles))
+	var firstErr error
+	for range lifecycles {
+		res := <-results
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			continue
+		}
+		started[res.index] = true
+	}
+
+	ok := make([]Lifecycle, 0, len(lifecycles))
+	for i, l := range lifecycles {
+		if started[i] {
+			ok = append(ok, l)
+		}
+	}
+	return ok, firstErr
+}
+
+//stopLifecycles stops every app in started, in reverse order, giving each at
+//most r.shutdownTimeout to return, and aggregates the resulting errors into
+//the returned multierror
+func (r *Runner) stopLifecycles(ctx context.Context, started []Lifecycle) *multierror.Error {
+	multiErrs := &multierror.Error{}
+
+	for i := len(started) - 1; i >= 0; i-- {
+		stopCtx := ctx
+		cancel := func() {}
+		if r.shutdownTimeout > 0 {
+			stopCtx, cancel = context.WithTimeout(ctx, r.shutdownTimeout)
+		}
+
+		if err := started[i].Stop(stopCtx); err != nil {
+			multiErrs = multierror.Append(multiErrs, err)
+		}
+
+		cancel()
+	}
+
+	return multiErrs
+}
@@ -0,0 +1,217 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+//recorder captures an ordered, concurrency-safe log of events for assertions
+//on call order across goroutines
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, s)
+}
+
+func (r *recorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, len(r.calls))
+	copy(out, r.calls)
+	return out
+}
+
+//fakeLifecycle is a Runnable implementing Lifecycle whose Start/Stop/Run
+//calls are all logged to a shared recorder for ordering assertions
+type fakeLifecycle struct {
+	name     string
+	rec      *recorder
+	startErr error
+	runErr   error
+	block    chan struct{}
+}
+
+func (f *fakeLifecycle) Start(ctx context.Context) error {
+	f.rec.add(f.name + ":start")
+	return f.startErr
+}
+
+func (f *fakeLifecycle) Stop(ctx context.Context) error {
+	f.rec.add(f.name + ":stop")
+	return nil
+}
+
+func (f *fakeLifecycle) Run(ctx context.Context) error {
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+		}
+	}
+	f.rec.add(f.name + ":run")
+	return f.runErr
+}
+
+func TestStopLifecycles_ReverseOrder(t *testing.T) {
+	rec := &recorder{}
+	started := []Lifecycle{
+		&fakeLifecycle{name: "a", rec: rec},
+		&fakeLifecycle{name: "b", rec: rec},
+		&fakeLifecycle{name: "c", rec: rec},
+	}
+
+	r := &Runner{}
+	if err := r.stopLifecycles(context.Background(), started).ErrorOrNil(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"c:stop", "b:stop", "a:stop"}
+	if got := rec.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stop order = %v, want %v", got, want)
+	}
+}
+
+func TestStopLifecycles_OnlyStopsGivenApps(t *testing.T) {
+	//never-started is a Lifecycle that belongs to the Runner but is
+	//deliberately withheld from stopLifecycles, the way Run withholds apps
+	//whose Start never ran or failed
+	rec := &recorder{}
+	neverStarted := &fakeLifecycle{name: "never-started", rec: rec}
+	started := &fakeLifecycle{name: "started", rec: rec}
+
+	r := &Runner{apps: []Runnable{neverStarted, started}}
+	r.stopLifecycles(context.Background(), []Lifecycle{started})
+
+	want := []string{"started:stop"}
+	if got := rec.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v (only the given app may be stopped)", got, want)
+	}
+}
+
+func TestStartLifecycles_Sequential_ReturnsOnlyStartedPrefix(t *testing.T) {
+	rec := &recorder{}
+	failErr := errors.New("boom")
+
+	a := &fakeLifecycle{name: "a", rec: rec}
+	b := &fakeLifecycle{name: "b", rec: rec, startErr: failErr}
+	c := &fakeLifecycle{name: "c", rec: rec}
+
+	r := &Runner{apps: []Runnable{a, b, c}, startupOrder: Sequential}
+
+	started, err := r.startLifecycles(context.Background())
+	if !errors.Is(err, failErr) {
+		t.Fatalf("err = %v, want %v", err, failErr)
+	}
+	if len(started) != 1 || started[0] != Lifecycle(a) {
+		t.Fatalf("started = %v, want only a", started)
+	}
+
+	want := []string{"a:start", "b:start"}
+	if got := rec.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v (c must never be started once b fails)", got, want)
+	}
+}
+
+func TestStartLifecycles_Parallel_ReturnsOnlySucceededInRegistrationOrder(t *testing.T) {
+	rec := &recorder{}
+	failErr := errors.New("boom")
+
+	a := &fakeLifecycle{name: "a", rec: rec}
+	b := &fakeLifecycle{name: "b", rec: rec, startErr: failErr}
+	c := &fakeLifecycle{name: "c", rec: rec}
+
+	r := &Runner{apps: []Runnable{a, b, c}, startupOrder: Parallel}
+
+	started, err := r.startLifecycles(context.Background())
+	if !errors.Is(err, failErr) {
+		t.Fatalf("err = %v, want %v", err, failErr)
+	}
+
+	want := []Lifecycle{a, c}
+	if !reflect.DeepEqual(started, want) {
+		t.Fatalf("started = %v, want %v (registration order, b excluded)", started, want)
+	}
+}
+
+func TestRunner_Run_SequentialStartFailure_OnlyStartedAppRunAndStopped(t *testing.T) {
+	rec := &recorder{}
+	failErr := errors.New("boom")
+
+	a := &fakeLifecycle{name: "a", rec: rec}
+	b := &fakeLifecycle{name: "b", rec: rec, startErr: failErr}
+	c := &fakeLifecycle{name: "c", rec: rec}
+
+	r := NewRunner(a, b, c).WithStartupOrder(Sequential)
+
+	err := r.Run(context.Background())
+	if err == nil || !errors.Is(err, failErr) {
+		t.Fatalf("Run() err = %v, want it to wrap %v", err, failErr)
+	}
+
+	//a never got a chance to Run - its Start succeeded but b's failure aborts
+	//the whole Run before any app's Run goroutine is spawned - and is only
+	//ever Stopped, since it's the sole app that actually started
+	want := []string{"a:start", "b:start", "a:stop"}
+	if got := rec.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestRunner_Run_CleanCompletionDoesNotStopLifecycles(t *testing.T) {
+	rec := &recorder{}
+	a := &fakeLifecycle{name: "a", rec: rec}
+
+	r := NewRunner(a)
+
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"a:start", "a:run"}
+	if got := rec.snapshot(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("calls = %v, want %v (a clean finish must not be followed by Stop)", got, want)
+	}
+}
+
+func TestRunner_Run_PeerFailureStopsAllStartedLifecyclesInReverseOrder(t *testing.T) {
+	rec := &recorder{}
+	failErr := errors.New("boom")
+
+	a := &fakeLifecycle{name: "a", rec: rec, block: make(chan struct{})}
+	b := &fakeLifecycle{name: "b", rec: rec, block: make(chan struct{})}
+	failing := runnableFunc(func(ctx context.Context) error { return failErr })
+
+	r := NewRunner(a, b, failing).WithStartupOrder(Sequential)
+
+	err := r.Run(context.Background())
+	if !errors.Is(err, failErr) {
+		t.Fatalf("Run() err = %v, want it to wrap %v", err, failErr)
+	}
+
+	//a and b's Run calls unblock concurrently once the peer's failure
+	//cancels the shared context, so their relative order isn't fixed, but
+	//Sequential startup fixes the start order and reverse-order Stop fixes
+	//the stop order
+	got := rec.snapshot()
+	if len(got) != 6 {
+		t.Fatalf("calls = %v, want 6 entries", got)
+	}
+	if got[0] != "a:start" || got[1] != "b:start" {
+		t.Fatalf("calls = %v, want a:start then b:start first", got)
+	}
+	if got[4] != "b:stop" || got[5] != "a:stop" {
+		t.Fatalf("calls = %v, want b:stop then a:stop last (reverse of start order)", got)
+	}
+	middle := map[string]bool{got[2]: true, got[3]: true}
+	if !middle["a:run"] || !middle["b:run"] {
+		t.Fatalf("calls = %v, want a:run and b:run between the starts and the stops", got)
+	}
+}
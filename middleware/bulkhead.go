@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/bigmate/runner"
+)
+
+//Bulkhead wraps a Runnable so that at most maxConcurrent invocations of Run
+//execute at once; additional callers block until a slot frees up or ctx is
+//canceled. A single runner.Middleware returned by Bulkhead shares its
+//semaphore across every Runnable it wraps, so applying it to several apps
+//limits their combined concurrency rather than each app individually
+func Bulkhead(maxConcurrent int) runner.Middleware {
+	sem := make(chan struct{}, maxConcurrent)
+	return func(r runner.Runnable) runner.Runnable {
+		return runnableFunc(func(ctx context.Context) error {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+			return r.Run(ctx)
+		})
+	}
+}
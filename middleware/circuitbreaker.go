@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bigmate/runner"
+)
+
+//ErrCircuitOpen is returned by a Runnable wrapped with CircuitBreaker while
+//the breaker is open and rejecting attempts
+var ErrCircuitOpen = errors.New("middleware: circuit breaker open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+//CircuitBreaker wraps a Runnable so that after maxFailures consecutive
+//failures it stops calling Run, returning ErrCircuitOpen instead, for
+//cooldown. After cooldown elapses a single half-open probe is let through;
+//success closes the breaker again, failure reopens it for another cooldown
+func CircuitBreaker(maxFailures int, cooldown time.Duration) runner.Middleware {
+	return func(r runner.Runnable) runner.Runnable {
+		cb := &circuitBreaker{r: r, maxFailures: maxFailures, cooldown: cooldown}
+		return runnableFunc(cb.Run)
+	}
+}
+
+type circuitBreaker struct {
+	r           runner.Runnable
+	maxFailures int
+	cooldown    time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func (cb *circuitBreaker) Run(ctx context.Context) error {
+	if !cb.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := cb.r.Run(ctx)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if err != nil {
+		cb.failures++
+		if cb.state == breakerHalfOpen || cb.failures >= cb.maxFailures {
+			cb.state = breakerOpen
+			cb.openedAt = time.Now()
+		}
+		return err
+	}
+	cb.state = breakerClosed
+	cb.failures = 0
+	return nil
+}
+
+//allow reports whether a call should be let through, flipping an expired
+//open breaker into half-open and admitting the caller that does so as its
+//single probe; any other caller arriving while that probe is still in
+//flight is rejected rather than piling onto the half-open breaker too
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case breakerClosed:
+		return true
+	case breakerHalfOpen:
+		return false
+	default: // breakerOpen
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.state = breakerHalfOpen
+		return true
+	}
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_StateTransitions(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	cases := []struct {
+		name        string
+		maxFailures int
+		cooldown    time.Duration
+		results     []error // scripted results the wrapped Runnable returns, in order
+		wantErrs    []error // expected error returned by the wrapped call, in order
+	}{
+		{
+			name:        "a success resets the consecutive failure count",
+			maxFailures: 2,
+			cooldown:    time.Hour,
+			results:     []error{errBoom, nil, errBoom},
+			wantErrs:    []error{errBoom, nil, errBoom},
+		},
+		{
+			name:        "opens after maxFailures consecutive failures and rejects further attempts",
+			maxFailures: 2,
+			cooldown:    time.Hour,
+			results:     []error{errBoom, errBoom, errBoom},
+			wantErrs:    []error{errBoom, errBoom, ErrCircuitOpen},
+		},
+		{
+			name:        "half-open probe failure reopens the breaker",
+			maxFailures: 1,
+			cooldown:    0,
+			results:     []error{errBoom, errBoom},
+			wantErrs:    []error{errBoom, errBoom},
+		},
+		{
+			name:        "half-open probe success closes the breaker",
+			maxFailures: 1,
+			cooldown:    0,
+			results:     []error{errBoom, nil, errBoom},
+			wantErrs:    []error{errBoom, nil, errBoom},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			i := 0
+			inner := runnableFunc(func(ctx context.Context) error {
+				err := tc.results[i]
+				i++
+				return err
+			})
+
+			wrapped := CircuitBreaker(tc.maxFailures, tc.cooldown)(inner)
+
+			for attempt, want := range tc.wantErrs {
+				if err := wrapped.Run(context.Background()); err != want {
+					t.Fatalf("attempt %d: got %v, want %v", attempt, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_RejectsWithoutCallingInnerWhileOpen(t *testing.T) {
+	errBoom := errors.New("boom")
+	calls := 0
+	inner := runnableFunc(func(ctx context.Context) error {
+		calls++
+		return errBoom
+	})
+
+	wrapped := CircuitBreaker(1, time.Hour)(inner)
+
+	if err := wrapped.Run(context.Background()); err != errBoom {
+		t.Fatalf("first call: got %v, want %v", err, errBoom)
+	}
+	if err := wrapped.Run(context.Background()); err != ErrCircuitOpen {
+		t.Fatalf("second call: got %v, want %v", err, ErrCircuitOpen)
+	}
+	if calls != 1 {
+		t.Fatalf("inner Runnable called %d times while breaker was open, want 1", calls)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenAdmitsOnlyOneConcurrentProbe(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	hold := make(chan struct{})
+	var calls, probing int32
+	inner := runnableFunc(func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			//the call that trips the breaker must return immediately, not
+			//hold the probe slot open
+			return errBoom
+		}
+		atomic.AddInt32(&probing, 1)
+		<-hold // hold the probe open so concurrent callers overlap it
+		return errBoom
+	})
+
+	//a cooldown that has already elapsed by the time the concurrent callers
+	//run lets the first of them in as the half-open probe; allow()'s
+	//breakerHalfOpen branch never re-checks cooldown, so every other
+	//concurrent caller is rejected regardless of scheduling
+	wrapped := CircuitBreaker(1, time.Millisecond)(inner)
+
+	if err := wrapped.Run(context.Background()); err != errBoom {
+		t.Fatalf("tripping call: got %v, want %v", err, errBoom)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	const callers = 10
+	var rejected int32
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			if err := wrapped.Run(context.Background()); err == ErrCircuitOpen {
+				atomic.AddInt32(&rejected, 1)
+			}
+		}()
+	}
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&rejected) != callers-1 {
+		select {
+		case <-deadline:
+			t.Fatalf("only %d of %d callers were rejected after 1s", atomic.LoadInt32(&rejected), callers-1)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if got := atomic.LoadInt32(&probing); got != 1 {
+		t.Fatalf("%d concurrent probes reached the inner Runnable while half-open, want 1", got)
+	}
+
+	close(hold)
+	wg.Wait()
+}
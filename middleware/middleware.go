@@ -0,0 +1,17 @@
+//Package middleware provides built-in runner.Middleware implementations,
+//modeled on goresilience's chained-runner design. Each one wraps a
+//runner.Runnable and returns a new runner.Runnable with the same contract, so
+//they can be stacked with runner.Chain before handing the result to
+//runner.NewRunner
+package middleware
+
+import (
+	"context"
+)
+
+//runnableFunc adapts a plain func(context.Context) error to runner.Runnable
+type runnableFunc func(ctx context.Context) error
+
+func (f runnableFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
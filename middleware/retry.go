@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/bigmate/runner"
+)
+
+//BackoffFunc computes the delay to wait before the given retry attempt
+//(1-indexed) of a Retry middleware
+type BackoffFunc func(attempt int) time.Duration
+
+//ConstantBackoff returns a BackoffFunc that always waits d
+func ConstantBackoff(d time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration { return d }
+}
+
+//ExponentialBackoff returns a BackoffFunc that waits base*2^(attempt-1),
+//capped at max
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := base << uint(attempt-1)
+		if d <= 0 || d > max {
+			return max
+		}
+		return d
+	}
+}
+
+//Retry wraps a Runnable so that Run is attempted up to maxAttempts times
+//(including the first attempt) while it returns an error, waiting between
+//attempts as dictated by backoff. A nil backoff retries immediately. Run
+//returns as soon as ctx is canceled or the Runnable succeeds, and returns the
+//last error once attempts are exhausted
+func Retry(maxAttempts int, backoff BackoffFunc) runner.Middleware {
+	return func(r runner.Runnable) runner.Runnable {
+		return runnableFunc(func(ctx context.Context) error {
+			var err error
+			for attempt := 1; attempt <= maxAttempts; attempt++ {
+				if err = r.Run(ctx); err == nil {
+					return nil
+				}
+				if attempt == maxAttempts {
+					break
+				}
+				if backoff == nil {
+					if ctx.Err() != nil {
+						return err
+					}
+					continue
+				}
+				timer := time.NewTimer(backoff(attempt))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				case <-timer.C:
+				}
+			}
+			return err
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/bigmate/runner"
+)
+
+//Timeout wraps a Runnable so that each call to Run is bound to its own
+//context.WithTimeout, preventing a single hung attempt from blocking forever
+//regardless of the parent context's deadline
+func Timeout(d time.Duration) runner.Middleware {
+	return func(r runner.Runnable) runner.Runnable {
+		return runnableFunc(func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return r.Run(ctx)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+//EventKind identifies the kind of lifecycle event reported through LogFunc
+//and MetricsRecorder
+type EventKind int
+
+const (
+	//EventStart is reported right before an app's Run (or, for PoolRunner,
+	//dequeued job) begins
+	EventStart EventKind = iota
+	//EventStop is reported when an app's Run returns nil
+	EventStop
+	//EventError is reported when an app's Run returns a non-nil, non-panic
+	//error
+	EventError
+	//EventPanic is reported when a panic was recovered from an app's Run
+	EventPanic
+	//EventQueueWait is reported by PoolRunner once a queued app is picked up
+	//by a worker
+	EventQueueWait
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventStart:
+		return "start"
+	case EventStop:
+		return "stop"
+	case EventError:
+		return "error"
+	case EventPanic:
+		return "panic"
+	case EventQueueWait:
+		return "queue_wait"
+	default:
+		return "unknown"
+	}
+}
+
+//LogInfo describes a single lifecycle event for one app, passed to a LogFunc
+type LogInfo struct {
+	App     string
+	Index   int
+	Event   EventKind
+	Elapsed time.Duration
+	Err     error
+}
+
+//LogFunc is invoked for each app's start, stop, error, panic and (when using
+//PoolRunner) queue-wait events
+type LogFunc func(LogInfo)
+
+//MetricsRecorder receives the stop, error, panic and queue-wait events that
+//LogFunc gets, in a form suited to wiring into an operator's metrics stack,
+//e.g. Prometheus. Start events are reported only to LogFunc
+type MetricsRecorder interface {
+	//RecordRun is called once an app's Run returns, successfully or not
+	RecordRun(app string, elapsed time.Duration, err error)
+	//RecordPanic is called when a panic was recovered from an app's Run
+	RecordPanic(app string)
+	//RecordQueueWait is called by PoolRunner once a queued app is picked up
+	//by a worker
+	RecordQueueWait(app string, elapsed time.Duration)
+}
+
+//runAndReport runs a via runApp and reports its start/stop/error/panic
+//events through log and metrics, whichever are non-nil. It is shared by
+//Runner.Run and PoolRunner.work so the two don't classify events differently
+func runAndReport(ctx context.Context, index int, a Runnable, recoverPanics bool, log LogFunc, metrics MetricsRecorder) error {
+	name := appName(index, a)
+	if log != nil {
+		log(LogInfo{App: name, Index: index, Event: EventStart})
+	}
+
+	started := time.Now()
+	err := runApp(ctx, index, a, recoverPanics)
+	elapsed := time.Since(started)
+
+	event := EventStop
+	if err != nil {
+		event = EventError
+		if _, isPanic := err.(*PanicError); isPanic {
+			event = EventPanic
+			if metrics != nil {
+				metrics.RecordPanic(name)
+			}
+		}
+	}
+	if log != nil {
+		log(LogInfo{App: name, Index: index, Event: event, Elapsed: elapsed, Err: err})
+	}
+	if metrics != nil {
+		metrics.RecordRun(name, elapsed, err)
+	}
+
+	return err
+}
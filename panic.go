@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+//Named is an optional interface a Runnable may implement to give itself a
+//human readable name, used in panic reports, LogInfo and metrics labels
+type Named interface {
+	Name() string
+}
+
+//PanicError wraps a value recovered from a panicking Runnable so that it can
+//be reported through the same multierror channel as a normal returned error
+type PanicError struct {
+	App   string
+	Index int
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic recovered from app %q (index %d): %v\n%s", e.App, e.Index, e.Value, e.Stack)
+}
+
+//appName returns a's Name() if it implements Named, otherwise a positional
+//fallback based on its index in the Runner
+func appName(index int, a Runnable) string {
+	if n, ok := a.(Named); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("app[%d]", index)
+}
+
+//runApp runs a in ctx, recovering a panic into a *PanicError when recoverPanics
+//is true. It is shared by Runner and PoolRunner
+func runApp(ctx context.Context, index int, a Runnable, recoverPanics bool) (err error) {
+	if recoverPanics {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{
+					App:   appName(index, a),
+					Index: index,
+					Value: v,
+					Stack: debug.Stack(),
+				}
+			}
+		}()
+	}
+	return a.Run(ctx)
+}
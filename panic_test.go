@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type namedApp struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func (a *namedApp) Name() string                 { return a.name }
+func (a *namedApp) Run(ctx context.Context) error { return a.run(ctx) }
+
+func TestRunApp_PanicRecovery(t *testing.T) {
+	cases := []struct {
+		name          string
+		recoverPanics bool
+		app           Runnable
+		wantPanicErr  bool
+		wantErr       error
+	}{
+		{
+			name:          "panic recovered into PanicError",
+			recoverPanics: true,
+			app:           &namedApp{name: "flaky", run: func(ctx context.Context) error { panic("kaboom") }},
+			wantPanicErr:  true,
+		},
+		{
+			name:          "normal error passes through untouched",
+			recoverPanics: true,
+			app:           &namedApp{name: "ok", run: func(ctx context.Context) error { return errBoomPanicTest }},
+			wantErr:       errBoomPanicTest,
+		},
+		{
+			name:          "no panic means nil error",
+			recoverPanics: true,
+			app:           &namedApp{name: "clean", run: func(ctx context.Context) error { return nil }},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := runApp(context.Background(), 0, tc.app, tc.recoverPanics)
+
+			if tc.wantPanicErr {
+				var pe *PanicError
+				if !errors.As(err, &pe) {
+					t.Fatalf("got %v (%T), want a *PanicError", err, err)
+				}
+				if pe.App != "flaky" || pe.Value != "kaboom" {
+					t.Fatalf("PanicError = %+v, want App=flaky Value=kaboom", pe)
+				}
+				if !strings.Contains(pe.Error(), "kaboom") {
+					t.Fatalf("PanicError.Error() = %q, want it to mention the panic value", pe.Error())
+				}
+				return
+			}
+
+			if err != tc.wantErr {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunApp_PanicPropagatesWithoutRecover(t *testing.T) {
+	app := &namedApp{name: "flaky", run: func(ctx context.Context) error { panic("kaboom") }}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected the panic to propagate when recoverPanics is false")
+		}
+	}()
+
+	_ = runApp(context.Background(), 0, app, false)
+}
+
+var errBoomPanicTest = errors.New("boom")
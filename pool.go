@@ -0,0 +1,245 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+//errPoolNotRunning is returned by Submit when called before Run has started
+//or after it has returned
+var errPoolNotRunning = errors.New("runner: PoolRunner is not running")
+
+//PoolRunner runs Runnables on a bounded worker pool instead of spawning one
+//goroutine per app like Runner does. Apps passed to the constructor or Add
+//are submitted as soon as Run starts; further apps may be queued at any
+//point afterwards by calling Submit, which is what makes PoolRunner suited
+//to workloads that add many short-lived apps over time (e.g. per-request
+//workers) rather than a single fixed batch. Workers are spawned lazily, up
+//to max at a time, and an idle worker - one that sat without a queued app
+//for idleTimeout - retires, so a burst of work spins workers up and a quiet
+//pool lets them go back down
+type PoolRunner struct {
+	initial []Runnable
+
+	max           int
+	idleTimeout   time.Duration
+	recoverPanics bool
+	logFunc       LogFunc
+	metrics       MetricsRecorder
+
+	mu      sync.Mutex
+	seq     int
+	workers int
+	jobs    chan poolJob
+	state   *poolRunState
+	retire  chan struct{}
+}
+
+//poolRunState holds the bookkeeping for a single in-flight call to Run; a
+//fresh one is created each time Run starts so Submit can reach the right
+//cancellation/error-aggregation state for whichever Run call is active
+type poolRunState struct {
+	ctx    *contextWithCancellableReason
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	multiErrs *multierror.Error
+	wg        sync.WaitGroup
+}
+
+//NewPoolRunner returns a *PoolRunner that runs at most max apps concurrently,
+//retiring idle workers after idleTimeout without a queued app. A max <= 0
+//means unbounded concurrency, matching Runner's behavior; an idleTimeout <= 0
+//means workers never retire early. apps are queued as soon as Run starts,
+//exactly as if passed to Submit
+func NewPoolRunner(max int, idleTimeout time.Duration, apps ...Runnable) *PoolRunner {
+	return &PoolRunner{initial: apps, max: max, idleTimeout: idleTimeout, recoverPanics: true}
+}
+
+//Add adds app to the collection of apps queued when Run starts and is not
+//thread safe; once Run is running, use Submit instead
+func (p *PoolRunner) Add(app Runnable) *PoolRunner {
+	p.initial = append(p.initial, app)
+	return p
+}
+
+//WithRecover enables or disables panic recovery for the apps run by p,
+//mirroring Runner.WithRecover
+func (p *PoolRunner) WithRecover(enabled bool) *PoolRunner {
+	p.recoverPanics = enabled
+	return p
+}
+
+//WithLogFunc registers fn to be called with a LogInfo on each app's start,
+//stop, error, panic and queue-wait events, mirroring Runner.WithLogFunc
+func (p *PoolRunner) WithLogFunc(fn LogFunc) *PoolRunner {
+	p.logFunc = fn
+	return p
+}
+
+//WithMetrics registers m to receive each app's stop, error, panic and
+//queue-wait events, mirroring Runner.WithMetrics
+func (p *PoolRunner) WithMetrics(m MetricsRecorder) *PoolRunner {
+	p.metrics = m
+	return p
+}
+
+//logEvent reports info through p.logFunc, if one is registered
+func (p *PoolRunner) logEvent(info LogInfo) {
+	if p.logFunc != nil {
+		p.logFunc(info)
+	}
+}
+
+type poolJob struct {
+	index    int
+	app      Runnable
+	queuedAt time.Time
+}
+
+//Run starts p's worker pool and blocks until ctx is done or an app's Run
+//returns an error, at which point every other app is terminated by canceling
+//the context passed into their Run method, same as Runner.Run, and the
+//failures are aggregated into the returned multierror. Apps queued by the
+//constructor or Add are submitted first; Submit may be called concurrently
+//with Run to queue more for as long as it keeps running
+func (p *PoolRunner) Run(ctx context.Context) error {
+	cctx, cancel := withCancelCause(ctx)
+	defer cancel()
+
+	state := &poolRunState{ctx: cctx, cancel: cancel, multiErrs: &multierror.Error{}}
+
+	p.mu.Lock()
+	p.jobs = make(chan poolJob)
+	p.workers = 0
+	p.state = state
+	p.retire = make(chan struct{})
+	p.mu.Unlock()
+
+	for _, a := range p.initial {
+		a := a
+		//Submit's own error only ever means ctx was already done when it
+		//tried to hand a off to a worker; that's not a new failure beyond
+		//whatever is already unwinding the pool, so it's not aggregated here
+		go func() { _ = p.Submit(cctx, a) }()
+	}
+
+	<-cctx.Done()
+
+	//workers and any in-flight Submit calls all select on cctx.Done() too, so
+	//there's no need to (and, since a Submit could be mid-send, no safe way
+	//to) close the jobs channel here - clearing the pointers just stops new
+	//Submit calls from being accepted
+	p.mu.Lock()
+	p.jobs = nil
+	p.state = nil
+	p.mu.Unlock()
+
+	state.wg.Wait()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.multiErrs.ErrorOrNil()
+}
+
+//Submit queues app to run on p's worker pool, spawning a new worker if fewer
+//than max are currently alive (or unconditionally when max <= 0). It blocks
+//until a worker accepts app, ctx is done, or p stops running, whichever
+//comes first. Submit must be called while Run is executing
+func (p *PoolRunner) Submit(ctx context.Context, app Runnable) error {
+	for {
+		p.mu.Lock()
+		jobs := p.jobs
+		state := p.state
+		if jobs == nil || state == nil {
+			p.mu.Unlock()
+			return errPoolNotRunning
+		}
+
+		if p.max <= 0 || p.workers < p.max {
+			p.workers++
+			p.spawnWorker(jobs, state)
+		}
+
+		p.seq++
+		job := poolJob{index: p.seq, app: app, queuedAt: time.Now()}
+		retired := p.retire
+		p.mu.Unlock()
+
+		select {
+		case jobs <- job:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-state.ctx.Done():
+			return state.ctx.Err()
+		case <-retired:
+			//a worker retired (idle timeout or shutdown) while we were
+			//waiting for one to free up; p.workers may have dropped below
+			//max in the meantime, so loop back and re-evaluate whether to
+			//spawn a replacement instead of sitting on a pool that only
+			//looked full a moment ago
+		}
+	}
+}
+
+//spawnWorker starts a worker goroutine that pulls jobs off jobs, reporting
+//to st, until st.ctx is done or it has sat idle for p.idleTimeout
+func (p *PoolRunner) spawnWorker(jobs chan poolJob, st *poolRunState) {
+	st.wg.Add(1)
+	go func() {
+		defer st.wg.Done()
+		defer func() {
+			p.mu.Lock()
+			p.workers--
+			close(p.retire)
+			p.retire = make(chan struct{})
+			p.mu.Unlock()
+		}()
+
+		for {
+			var timer *time.Timer
+			var idle <-chan time.Time
+			if p.idleTimeout > 0 {
+				timer = time.NewTimer(p.idleTimeout)
+				idle = timer.C
+			}
+
+			select {
+			case j, ok := <-jobs:
+				if timer != nil {
+					timer.Stop()
+				}
+				if !ok {
+					return
+				}
+
+				name := appName(j.index, j.app)
+				wait := time.Since(j.queuedAt)
+				p.logEvent(LogInfo{App: name, Index: j.index, Event: EventQueueWait, Elapsed: wait})
+				if p.metrics != nil {
+					p.metrics.RecordQueueWait(name, wait)
+				}
+
+				if err := runAndReport(st.ctx, j.index, j.app, p.recoverPanics, p.logFunc, p.metrics); err != nil {
+					st.mu.Lock()
+					st.multiErrs = multierror.Append(st.multiErrs, err)
+					st.ctx.cancelCause(err)
+					st.cancel()
+					st.mu.Unlock()
+				}
+			case <-idle:
+				return
+			case <-st.ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+		}
+	}()
+}
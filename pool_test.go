@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//runnableFunc adapts a plain func(context.Context) error to Runnable, for
+//tests that don't need a dedicated type
+type runnableFunc func(ctx context.Context) error
+
+func (f runnableFunc) Run(ctx context.Context) error { return f(ctx) }
+
+//blockingApp runs until ctx is done, unless failAfter elapses first, in
+//which case it returns failErr
+type blockingApp struct {
+	failAfter time.Duration
+	failErr   error
+	ran       int32
+}
+
+func (a *blockingApp) Run(ctx context.Context) error {
+	atomic.AddInt32(&a.ran, 1)
+	if a.failAfter <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+	select {
+	case <-time.After(a.failAfter):
+		return a.failErr
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func TestPoolRunner_CancelAllOnError(t *testing.T) {
+	//4 apps total (1 failing + 3 peers) that block until canceled; "bounded"
+	//gives max exactly enough capacity for all of them to get a worker right
+	//away. A max smaller than the number of long-lived peers would let them
+	//monopolize every worker forever, so the failing app - stuck queued
+	//behind them - would never run and never trigger the cancellation this
+	//test is checking for; that's bounded concurrency working as designed,
+	//not something for PoolRunner to work around
+	cases := []struct {
+		name string
+		max  int
+	}{
+		{name: "bounded", max: 4},
+		{name: "unbounded", max: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			failErr := errors.New("boom")
+			//generous enough that all 4 initial Submit calls can rendezvous
+			//with their freshly spawned workers before the failing app
+			//returns and cancels the pool out from under them
+			failing := &blockingApp{failAfter: 200 * time.Millisecond, failErr: failErr}
+			peers := []*blockingApp{{}, {}, {}}
+
+			pool := NewPoolRunner(tc.max, 0, failing, peers[0], peers[1], peers[2])
+
+			done := make(chan error, 1)
+			go func() { done <- pool.Run(context.Background()) }()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, failErr) {
+					t.Fatalf("expected error chain to contain %v, got %v", failErr, err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Run did not return after a peer failed; cancellation did not propagate")
+			}
+
+			for i, p := range peers {
+				if atomic.LoadInt32(&p.ran) == 0 {
+					t.Fatalf("peer %d never started", i)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolRunner_SubmitAfterStart(t *testing.T) {
+	pool := NewPoolRunner(1, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- pool.Run(ctx) }()
+
+	time.Sleep(10 * time.Millisecond)
+
+	var ran int32
+	app := runnableFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	})
+
+	if err := pool.Submit(context.Background(), app); err != nil {
+		t.Fatalf("Submit returned unexpected error: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&ran) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("submitted app never ran")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+}
+
+func TestPoolRunner_IdleWorkerRetiresThenNewSubmitSpawnsReplacement(t *testing.T) {
+	pool := NewPoolRunner(1, 5*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- pool.Run(ctx) }()
+
+	time.Sleep(10 * time.Millisecond)
+
+	var first, second int32
+	mark := func(flag *int32) runnableFunc {
+		return runnableFunc(func(ctx context.Context) error {
+			atomic.AddInt32(flag, 1)
+			return nil
+		})
+	}
+
+	if err := pool.Submit(context.Background(), mark(&first)); err != nil {
+		t.Fatalf("first Submit: %v", err)
+	}
+
+	//give the sole worker time to finish job 1 and then retire after sitting
+	//idle past idleTimeout, so the pool is back down to 0 workers
+	time.Sleep(30 * time.Millisecond)
+
+	if err := pool.Submit(context.Background(), mark(&second)); err != nil {
+		t.Fatalf("second Submit: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&second) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("second submitted app never ran; no replacement worker was spawned after the first retired")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+}
+
+func TestPoolRunner_SubmitAfterStopReturnsError(t *testing.T) {
+	pool := NewPoolRunner(1, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- pool.Run(ctx) }()
+
+	cancel()
+	<-done
+
+	if err := pool.Submit(context.Background(), runnableFunc(func(ctx context.Context) error { return nil })); err != errPoolNotRunning {
+		t.Fatalf("expected errPoolNotRunning, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+//Package prommetrics implements runner.MetricsRecorder on top of Prometheus
+//counters and a histogram, for operators who already wire their metrics
+//stack through a prometheus.Registerer
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//Recorder implements runner.MetricsRecorder, tracking run/failure/panic
+//counters and a run duration histogram, labeled by app name
+type Recorder struct {
+	runs      *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	panics    *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	queueWait *prometheus.HistogramVec
+}
+
+//NewRecorder creates a Recorder and registers its metrics with reg under
+//namespace
+func NewRecorder(reg prometheus.Registerer, namespace string) *Recorder {
+	r := &Recorder{
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "runner_runs_total",
+			Help:      "Total number of Runnable.Run completions, by app.",
+		}, []string{"app"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "runner_failures_total",
+			Help:      "Total number of Runnable.Run calls that returned an error, by app.",
+		}, []string{"app"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "runner_panics_total",
+			Help:      "Total number of panics recovered from a Runnable, by app.",
+		}, []string{"app"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "runner_run_duration_seconds",
+			Help:      "Duration of Runnable.Run calls, by app.",
+		}, []string{"app"}),
+		queueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "runner_queue_wait_seconds",
+			Help:      "Time a Runnable spent queued before a PoolRunner worker picked it up, by app.",
+		}, []string{"app"}),
+	}
+
+	reg.MustRegister(r.runs, r.failures, r.panics, r.duration, r.queueWait)
+
+	return r
+}
+
+//RecordRun implements runner.MetricsRecorder
+func (r *Recorder) RecordRun(app string, elapsed time.Duration, err error) {
+	r.runs.WithLabelValues(app).Inc()
+	r.duration.WithLabelValues(app).Observe(elapsed.Seconds())
+	if err != nil {
+		r.failures.WithLabelValues(app).Inc()
+	}
+}
+
+//RecordPanic implements runner.MetricsRecorder
+func (r *Recorder) RecordPanic(app string) {
+	r.panics.WithLabelValues(app).Inc()
+}
+
+//RecordQueueWait implements runner.MetricsRecorder
+func (r *Recorder) RecordQueueWait(app string, elapsed time.Duration) {
+	r.queueWait.WithLabelValues(app).Observe(elapsed.Seconds())
+}
@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 )
@@ -16,11 +17,17 @@ type Runnable interface {
 //Runner is a parent app for apps to be run
 type Runner struct {
 	apps []Runnable
+
+	recoverPanics   bool
+	startupOrder    StartupOrder
+	shutdownTimeout time.Duration
+	logFunc         LogFunc
+	metrics         MetricsRecorder
 }
 
 //NewRunner returns *Runner
 func NewRunner(apps ...Runnable) *Runner {
-	return &Runner{apps: apps}
+	return &Runner{apps: apps, recoverPanics: true}
 }
 
 // Add adds the Runnable into collection of Apps to be run and is not thread safe
@@ -29,32 +36,90 @@ func (r *Runner) Add(app Runnable) *Runner {
 	return r
 }
 
+//WithRecover enables or disables panic recovery for the apps run by r.
+//Recovery is enabled by default, converting a panicking Runnable into a
+//*PanicError instead of crashing the whole process; pass false to restore
+//the previous behavior
+func (r *Runner) WithRecover(enabled bool) *Runner {
+	r.recoverPanics = enabled
+	return r
+}
+
+//WithStartupOrder controls whether apps implementing Lifecycle are started
+//concurrently or one at a time; see StartupOrder. The default is Parallel
+func (r *Runner) WithStartupOrder(order StartupOrder) *Runner {
+	r.startupOrder = order
+	return r
+}
+
+//WithShutdownTimeout sets the grace period given to each Lifecycle app's
+//Stop call during shutdown. Zero, the default, means no deadline is imposed
+func (r *Runner) WithShutdownTimeout(d time.Duration) *Runner {
+	r.shutdownTimeout = d
+	return r
+}
+
+//WithLogFunc registers fn to be called with a LogInfo on each app's start,
+//stop, error and panic events
+func (r *Runner) WithLogFunc(fn LogFunc) *Runner {
+	r.logFunc = fn
+	return r
+}
+
+//WithMetrics registers m to receive each app's stop, error and panic events
+//in a form suited to an operator's metrics stack; see MetricsRecorder
+func (r *Runner) WithMetrics(m MetricsRecorder) *Runner {
+	r.metrics = m
+	return r
+}
+
 //Run runs apps passed to the constructor concurrently,
 //if one of them fails all the other running apps will be terminated
-//by canceling context passed into their Run method
+//by canceling context passed into their Run method. Peers observing
+//ctx.Err() after cancellation get back an error wrapping context.Canceled
+//and the error of the app that caused it, instead of the generic
+//context.Canceled alone
 func (r *Runner) Run(ctx context.Context) error {
 	mu := sync.Mutex{}
 	wg := sync.WaitGroup{}
 	multiErrs := &multierror.Error{}
 
-	ctx, cancel := context.WithCancel(ctx)
+	cctx, cancel := withCancelCause(ctx)
 	defer cancel()
 
+	started, err := r.startLifecycles(cctx)
+	if err != nil {
+		multiErrs = multierror.Append(multiErrs, err)
+		cctx.cancelCause(err)
+		cancel()
+
+		multiErrs = multierror.Append(multiErrs, r.stopLifecycles(context.Background(), started).Errors...)
+		return multiErrs.ErrorOrNil()
+	}
+
 	wg.Add(len(r.apps))
 
-	for _, a := range r.apps {
-		go func(a Runnable) {
+	for i, a := range r.apps {
+		go func(i int, a Runnable) {
 			defer wg.Done()
-			if err := a.Run(ctx); err != nil {
+			if err := runAndReport(cctx, i, a, r.recoverPanics, r.logFunc, r.metrics); err != nil {
 				mu.Lock()
 				multiErrs = multierror.Append(multiErrs, err)
+				cctx.cancelCause(err)
 				cancel()
 				mu.Unlock()
 			}
-		}(a)
+		}(i, a)
 	}
 
 	wg.Wait()
 
+	//only tear down Lifecycle apps if shutdown was actually triggered, either
+	//by the parent context or by a peer's failure; a run that completed
+	//cleanly on its own leaves already-finished apps alone
+	if cctx.Err() != nil {
+		multiErrs = multierror.Append(multiErrs, r.stopLifecycles(context.Background(), started).Errors...)
+	}
+
 	return multiErrs.ErrorOrNil()
 }